@@ -6,10 +6,7 @@
 
 package fmt
 
-import (
-	"errors"
-	"io"
-)
+import "io"
 
 // State represents the printer state passed to custom formatters.
 // It provides access to the io.Writer interface plus information about
@@ -61,39 +58,41 @@ func Sprintln(v ...interface{}) string {
 // Spaces are added between operands when neither is a string.
 // It returns the number of bytes written and any write error encountered.
 func Print(v ...interface{}) (int, error) {
-	return 0, nil
+	return quickStdout(false, v...)
 }
 
 // Println formats using the default formats for its operands and writes to standard output.
 // Spaces are always added between operands and a newline is appended.
 // It returns the number of bytes written and any write error encountered.
 func Println(v ...interface{}) (int, error) {
-	return 0, nil
+	return quickStdout(true, v...)
 }
 
 // Errorf formats according to a format specifier and returns the string as a
 // value that satisfies error.
 //
 // If the format specifier includes a %w verb with an error operand,
-// the returned error will implement an Unwrap method returning the operand. It is
-// invalid to include more than one %w verb or to supply it with an operand
-// that does not implement the error interface. The %w verb is otherwise
-// a synonym for %v.
+// the returned error will implement an Unwrap method returning the operand.
+// If there is more than one %w verb, the returned error will implement an
+// Unwrap method returning a []error containing all the %w operands in the
+// order they appear in the arguments.
+// It is invalid to supply the %w verb with an operand that does not implement
+// the error interface. The %w verb is otherwise a synonym for %v.
 func Errorf(s string, v ...interface{}) error {
-	return errors.New(quickPrintf(s, v...))
+	return quickErrorf(s, v...)
 }
 
 // Append formats using the default formats for its operands, appends the result to
 // the byte slice, and returns the updated slice.
 func Append(b []byte, v ...interface{}) []byte {
-	return append(b, []byte(quickPrint(false, v...))...)
+	return quickAppend(b, false, v...)
 }
 
 // Appendln formats using the default formats for its operands, appends the result
 // to the byte slice, and returns the updated slice. Spaces are always added
 // between operands and a newline is appended.
 func Appendln(b []byte, v ...interface{}) []byte {
-	return append(b, []byte(quickPrint(true, v...))...)
+	return quickAppend(b, true, v...)
 }
 
 // Sprintf formats according to a format specifier and returns the resulting string.
@@ -104,7 +103,7 @@ func Sprintf(s string, v ...interface{}) string {
 // Printf formats according to a format specifier and writes to standard output.
 // It returns the number of bytes written and any write error encountered.
 func Printf(s string, v ...interface{}) (int, error) {
-	return 0, nil
+	return quickStdoutf(s, v...)
 }
 
 // Fprint formats using the default formats for its operands and writes to w.
@@ -124,7 +123,7 @@ func Fprintln(w io.Writer, v ...interface{}) (int, error) {
 // Appendf formats according to a format specifier, appends the result to the byte
 // slice, and returns the updated slice.
 func Appendf(b []byte, s string, v ...interface{}) []byte {
-	return append(b, []byte(quickPrintf(s, v...))...)
+	return quickAppendf(b, s, v...)
 }
 
 // Fprintf formats according to a format specifier and writes to w.
@@ -1,274 +1,1207 @@
 package fmt
 
 import (
+	"errors"
+	"hash/maphash"
+	"internal/abi"
+	"internal/reflectlite"
 	"io"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+	"unsafe"
 )
 
+const (
+	digits = "0123456789abcdefx"
+	upper  = "0123456789ABCDEFX"
+	spaces = "                                "
+	zeros  = "00000000000000000000000000000000"
+
+	maxPrograms = 0x400
+)
+
+var (
+	printers = sync.Pool{
+		New: func() interface{} { return new(printer) },
+	}
+	programs [maxPrograms]atomic.Pointer[cached]
+	sighted  [maxPrograms]atomic.Uint64
+	seed     = maphash.MakeSeed()
+)
+
+type flags struct {
+	wid, prec                       int
+	hasWid, hasPrec                 bool
+	minus, plus, sharp, space, zero bool
+	plusV, sharpV                   bool
+}
+type printer struct {
+	buf []byte
+	flags
+	errs []int
+	ops  []verb
+	seen [][2]uintptr
+	b    [0x44]byte
+
+	wrap, erroring     bool
+	reordered, goodArg bool
+	panicking          bool
+}
+type verb struct {
+	s string
+	f flags
+	c rune
+}
+type program struct {
+	v []verb
+	t string
+	n bool
+}
+type cached struct {
+	s string
+	r *program
+	u atomic.Bool
+}
 type stringer interface {
 	String() string
 }
+type wrapError struct {
+	e error
+	s string
+}
+type wrapErrors struct {
+	s string
+	e []error
+}
 
-func uitoa(v uint64) string {
-	if v == 0 {
-		return "0"
+func (e *wrapError) Error() string {
+	return e.s
+}
+func (e *wrapError) Unwrap() error {
+	return e.e
+}
+func (e *wrapErrors) Error() string {
+	return e.s
+}
+func (e *wrapErrors) Unwrap() []error {
+	return e.e
+}
+func newPrinter() *printer {
+	p := printers.Get().(*printer)
+	*p = printer{buf: p.buf[:0], errs: p.errs[:0], ops: p.ops[:0], seen: p.seen[:0]}
+	return p
+}
+func (p *printer) free() {
+	if cap(p.buf) > 0x10000 {
+		return
+	}
+	printers.Put(p)
+}
+func (p *printer) write(b []byte) {
+	p.buf = append(p.buf, b...)
+}
+func (p *printer) writeString(s string) {
+	p.buf = append(p.buf, s...)
+}
+func (p *printer) writeRune(r rune) {
+	p.buf = utf8.AppendRune(p.buf, r)
+}
+func (p *printer) writePad(n int) {
+	s := spaces
+	if p.zero && !p.minus {
+		s = zeros
+	}
+	for n > len(s) {
+		p.writeString(s)
+		n -= len(s)
+	}
+	if n > 0 {
+		p.writeString(s[:n])
+	}
+}
+func (p *printer) pad(s string) {
+	if !p.hasWid || p.wid == 0 {
+		p.writeString(s)
+		return
+	}
+	if n := p.wid - utf8.RuneCountInString(s); p.minus {
+		p.writeString(s)
+		p.writePad(n)
+	} else {
+		p.writePad(n)
+		p.writeString(s)
+	}
+}
+func (p *printer) padBytes(b []byte) {
+	if !p.hasWid || p.wid == 0 {
+		p.write(b)
+		return
+	}
+	if n := p.wid - utf8.RuneCount(b); p.minus {
+		p.write(b)
+		p.writePad(n)
+	} else {
+		p.writePad(n)
+		p.write(b)
+	}
+}
+func (p *printer) truncate(s string) string {
+	if !p.hasPrec {
+		return s
 	}
+	n := p.prec
+	for i := range s {
+		if n--; n < 0 {
+			return s[:i]
+		}
+	}
+	return s
+}
+func (p *printer) parse(s string, i int) int {
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case '#':
+			p.sharp = true
+		case '0':
+			p.zero = true
+		case '+':
+			p.plus = true
+		case '-':
+			p.minus = true
+		case ' ':
+			p.space = true
+		default:
+			return i
+		}
+	}
+	return i
+}
+func (p *printer) argNumber(a int, s string, i, n int) (int, int, bool) {
+	if len(s) <= i || s[i] != '[' {
+		return a, i, false
+	}
+	p.reordered = true
+	x, w, ok := parseArgNumber(s[i:])
+	if ok && x >= 0 && x < n {
+		return x, i + w, true
+	}
+	p.goodArg = false
+	return a, i + w, ok
+}
+func (p *printer) fmtBool(v bool) {
+	if v {
+		p.pad("true")
+	} else {
+		p.pad("false")
+	}
+}
+func (p *printer) fmtS(s string) {
+	p.pad(p.truncate(s))
+}
+func (p *printer) fmtQ(s string) {
+	switch s = p.truncate(s); {
+	case p.sharp && strconv.CanBackquote(s):
+		p.padBytes(append(append(append(p.b[:0], '`'), s...), '`'))
+	case p.plus:
+		p.padBytes(strconv.AppendQuoteToASCII(p.b[:0], s))
+	default:
+		p.padBytes(strconv.AppendQuote(p.b[:0], s))
+	}
+}
+func (p *printer) fmtQc(v uint64) {
+	r := rune(v)
+	if v > utf8.MaxRune {
+		r = utf8.RuneError
+	}
+	if p.plus {
+		p.padBytes(strconv.AppendQuoteRuneToASCII(p.b[:0], r))
+	} else {
+		p.padBytes(strconv.AppendQuoteRune(p.b[:0], r))
+	}
+}
+func (p *printer) fmtC(v uint64) {
+	r := rune(v)
+	if v > utf8.MaxRune {
+		r = utf8.RuneError
+	}
+	p.padBytes(utf8.AppendRune(p.b[:0], r))
+}
+func (p *printer) fmtU(v uint64) {
 	var (
-		i = 0x13
-		b [20]byte
+		b = p.b[:]
+		n = 4
 	)
-	for v >= 0xA {
-		n := v / 0xA
-		b[i] = byte(0x30 + v - n*0xA)
+	if p.hasPrec && p.prec > 4 {
+		if n = p.prec; n+0xA > len(b) {
+			b = make([]byte, n+0xA)
+		}
+	}
+	i := len(b)
+	if p.sharp && v <= utf8.MaxRune && strconv.IsPrint(rune(v)) {
 		i--
-		v = n
+		b[i] = '\''
+		i -= utf8.RuneLen(rune(v))
+		utf8.EncodeRune(b[i:], rune(v))
+		i -= 2
+		b[i], b[i+1] = ' ', '\''
 	}
-	b[i] = byte(0x30 + v)
-	return string(b[i:])
+	for ; v >= 0x10; v >>= 4 {
+		i, n = i-1, n-1
+		b[i] = upper[v&0xF]
+	}
+	i, n = i-1, n-1
+	b[i] = upper[v]
+	for ; n > 0; n-- {
+		i--
+		b[i] = '0'
+	}
+	i -= 2
+	b[i], b[i+1] = 'U', '+'
+	z := p.zero
+	p.zero = false
+	p.padBytes(b[i:])
+	p.zero = z
 }
-func quickPrint(nl bool, v ...interface{}) string {
-	var b strings.Builder
-	quickFprint(&b, nl, v...)
-	r := b.String()
-	b.Reset()
-	return r
+func (p *printer) fmtSx(s, d string) {
+	n := len(s)
+	if p.hasPrec && p.prec < n {
+		n = p.prec
+	}
+	if n == 0 {
+		if p.hasWid {
+			p.writePad(p.wid)
+		}
+		return
+	}
+	w := n * 2
+	if p.space {
+		if p.sharp {
+			w *= 2
+		}
+		w += n - 1
+	} else if p.sharp {
+		w += 2
+	}
+	if p.hasWid && p.wid > w && !p.minus {
+		p.writePad(p.wid - w)
+	}
+	b := p.b[:0]
+	if p.sharp {
+		b = append(b, '0', d[0x10])
+	}
+	for i := 0; i < n; i++ {
+		if len(b) > len(p.b)-6 {
+			p.write(b)
+			b = p.b[:0]
+		}
+		if p.space && i > 0 {
+			if b = append(b, ' '); p.sharp {
+				b = append(b, '0', d[0x10])
+			}
+		}
+		b = append(b, d[s[i]>>4], d[s[i]&0xF])
+	}
+	p.write(b)
+	if p.hasWid && p.wid > w && p.minus {
+		p.writePad(p.wid - w)
+	}
 }
-func quickPrintf(s string, v ...interface{}) string {
-	var b strings.Builder
-	quickFprintf(&b, s, v...)
-	r := b.String()
-	b.Reset()
-	return r
+func (p *printer) fmtInteger(v uint64, s bool, d uint64, c rune) {
+	m := s && int64(v) < 0
+	if m {
+		v = -v
+	}
+	b := p.b[0:]
+	if p.hasWid || p.hasPrec {
+		if n := 3 + p.wid + p.prec; n > len(b) {
+			b = make([]byte, n)
+		}
+	}
+	var r int
+	if p.hasPrec {
+		if r = p.prec; r == 0 && v == 0 {
+			z := p.zero
+			p.zero = false
+			p.writePad(p.wid)
+			p.zero = z
+			return
+		}
+	} else if p.zero && !p.minus && p.hasWid {
+		if r = p.wid; m || p.plus || p.space {
+			r--
+		}
+	}
+	g := digits
+	if c == 'X' {
+		g = upper
+	}
+	i := len(b)
+	for v >= d {
+		n := v / d
+		i--
+		b[i] = g[v-n*d]
+		v = n
+	}
+	i--
+	for b[i] = g[v]; i > 0 && r > len(b)-i; {
+		i--
+		b[i] = '0'
+	}
+	if p.sharp {
+		switch d {
+		case 2:
+			i -= 2
+			b[i], b[i+1] = '0', 'b'
+		case 8:
+			if b[i] != '0' {
+				i--
+				b[i] = '0'
+			}
+		case 0x10:
+			i -= 2
+			b[i], b[i+1] = '0', g[0x10]
+		}
+	}
+	if c == 'O' {
+		i -= 2
+		b[i], b[i+1] = '0', 'o'
+	}
+	switch {
+	case m:
+		i--
+		b[i] = '-'
+	case p.plus:
+		i--
+		b[i] = '+'
+	case p.space:
+		i--
+		b[i] = ' '
+	}
+	z := p.zero
+	p.zero = false
+	p.padBytes(b[i:])
+	p.zero = z
 }
-func quickFprint(b io.Writer, f bool, v ...interface{}) (int, error) {
-	if len(v) == 0 {
-		return 0, nil
+
+func (p *printer) fmtFloat(v float64, n int, c byte, d int) {
+	if p.hasPrec {
+		d = p.prec
 	}
-	var (
-		s    bool
-		err  error
-		n, c int
-	)
-	for i := range v {
-		switch r := v[i].(type) {
-		case []byte:
-			if !s && i > 0 {
-				n, err = b.Write([]byte{' '})
-				if c += n; err != nil {
-					return c, err
-				}
+	b := strconv.AppendFloat(p.b[:1], v, c, d, n)
+	if b[1] == '-' || b[1] == '+' {
+		b = b[1:]
+	} else {
+		b[0] = '+'
+	}
+	if p.space && b[0] == '+' && !p.plus {
+		b[0] = ' '
+	}
+	if b[1] == 'I' || b[1] == 'N' {
+		z := p.zero
+		if p.zero = false; b[1] == 'N' && !p.space && !p.plus {
+			b = b[1:]
+		}
+		p.padBytes(b)
+		p.zero = z
+		return
+	}
+	if p.sharp && c != 'b' {
+		var (
+			t    [6]byte
+			k    = t[:0]
+			h, z bool
+		)
+		if n = 0; c == 'g' || c == 'G' || c == 'x' {
+			if n = d; n == -1 {
+				n = 6
 			}
-			s = true
-			n, err = io.WriteString(b, string(r))
-		case string:
-			if !s && i > 0 {
-				n, err = b.Write([]byte{' '})
-				if c += n; err != nil {
-					return c, err
+		}
+		for i := 1; i < len(b); i++ {
+			switch b[i] {
+			case '.':
+				h = true
+			case 'p', 'P':
+				k = append(k, b[i:]...)
+				b = b[:i]
+			case 'e', 'E':
+				if c != 'x' && c != 'X' {
+					k = append(k, b[i:]...)
+					b = b[:i]
+					break
 				}
-			}
-			s = true
-			n, err = io.WriteString(b, r)
-		case stringer:
-			if !s && i > 0 {
-				n, err = b.Write([]byte{' '})
-				if c += n; err != nil {
-					return c, err
+				fallthrough
+			default:
+				if b[i] != '0' {
+					z = true
 				}
-			}
-			s = true
-			n, err = io.WriteString(b, r.String())
-		default:
-			if s = false; i > 0 {
-				n, err = b.Write([]byte{' '})
-				if c += n; err != nil {
-					return c, err
+				if z {
+					n--
 				}
 			}
-			switch r := v[i].(type) {
-			case bool:
-				if r {
-					n, err = io.WriteString(b, "true")
-				} else {
-					n, err = io.WriteString(b, "false")
-				}
-			case float32:
-				n, err = io.WriteString(b, strconv.FormatFloat(float64(r), 'f', 2, 64))
-			case float64:
-				n, err = io.WriteString(b, strconv.FormatFloat(r, 'f', 2, 64))
-			case int:
-				n, err = io.WriteString(b, uitoa(uint64(r)))
-			case int8:
-				n, err = io.WriteString(b, uitoa(uint64(r)))
-			case int16:
-				n, err = io.WriteString(b, uitoa(uint64(r)))
-			case int32:
-				n, err = io.WriteString(b, uitoa(uint64(r)))
-			case int64:
-				n, err = io.WriteString(b, uitoa(uint64(r)))
-			case uint:
-				n, err = io.WriteString(b, uitoa(uint64(r)))
-			case uint8:
-				n, err = io.WriteString(b, uitoa(uint64(r)))
-			case uint16:
-				n, err = io.WriteString(b, uitoa(uint64(r)))
-			case uint32:
-				n, err = io.WriteString(b, uitoa(uint64(r)))
-			case uint64:
-				n, err = io.WriteString(b, uitoa(r))
-			case uintptr:
-				n, err = io.WriteString(b, uitoa(uint64(r)))
+		}
+		if !h {
+			if len(b) == 2 && b[1] == '0' {
+				n--
+			}
+			b = append(b, '.')
+		}
+		for ; n > 0; n-- {
+			b = append(b, '0')
+		}
+		b = append(b, k...)
+	}
+	if !p.plus && b[0] == '+' {
+		p.padBytes(b[1:])
+		return
+	}
+	if p.zero && !p.minus && p.hasWid && p.wid > len(b) {
+		p.write(b[:1])
+		p.writePad(p.wid - len(b))
+		p.write(b[1:])
+		return
+	}
+	p.padBytes(b)
+}
+func (p *printer) Flag(c int) bool {
+	switch c {
+	case '-':
+		return p.minus
+	case '+':
+		return p.plus || p.plusV
+	case '#':
+		return p.sharp || p.sharpV
+	case ' ':
+		return p.space
+	case '0':
+		return p.zero
+	}
+	return false
+}
+func (p *printer) Width() (int, bool) {
+	return p.wid, p.hasWid
+}
+func (p *printer) Precision() (int, bool) {
+	return p.prec, p.hasPrec
+}
+func (p *printer) Write(b []byte) (int, error) {
+	p.write(b)
+	return len(b), nil
+}
+func (p *printer) WriteString(s string) (int, error) {
+	p.writeString(s)
+	return len(s), nil
+}
+func (p *printer) badVerb(v interface{}, c rune) {
+	p.erroring = true
+	p.writeString("%!")
+	p.writeRune(c)
+	if p.writeString("("); v == nil {
+		p.writeString("<nil>")
+	} else {
+		p.writeString(reflectlite.TypeOf(v).String())
+		p.writeString("=")
+		p.printArg(v, 'v')
+	}
+	p.writeString(")")
+	p.erroring = false
+}
+func (p *printer) fmt0x64(v uint64, x bool) {
+	s := p.sharp
+	p.sharp = x
+	p.fmtInteger(v, false, 0x10, 'x')
+	p.sharp = s
+}
+func (p *printer) printBool(v bool, c rune) bool {
+	switch c {
+	case 't', 'v':
+		p.fmtBool(v)
+	default:
+		return false
+	}
+	return true
+}
+func (p *printer) printBytes(v []byte, c rune) bool {
+	if !p.sharpV {
+		if p.printByteList(v, c) {
+			return true
+		}
+		return p.printString(string(v), c)
+	}
+	if p.writeString("[]byte"); v == nil {
+		p.writeString("(nil)")
+		return true
+	}
+	p.writeString("{")
+	for i := range v {
+		if i > 0 {
+			p.writeString(", ")
+		}
+		p.fmt0x64(uint64(v[i]), true)
+	}
+	p.writeString("}")
+	return true
+}
+func (p *printer) printString(v string, c rune) bool {
+	switch c {
+	case 'v':
+		if p.sharpV {
+			p.fmtQ(v)
+		} else {
+			p.fmtS(v)
+		}
+	case 's':
+		p.fmtS(v)
+	case 'q':
+		p.fmtQ(v)
+	case 'x':
+		p.fmtSx(v, digits)
+	case 'X':
+		p.fmtSx(v, upper)
+	default:
+		return false
+	}
+	return true
+}
+func (p *printer) printInteger(v uint64, s bool, c rune) bool {
+	switch c {
+	case 'v':
+		if p.sharpV && !s {
+			p.fmt0x64(v, true)
+		} else {
+			p.fmtInteger(v, s, 0xA, c)
+		}
+	case 'b':
+		p.fmtInteger(v, s, 2, c)
+	case 'o', 'O':
+		p.fmtInteger(v, s, 8, c)
+	case 'd':
+		p.fmtInteger(v, s, 0xA, c)
+	case 'x', 'X':
+		p.fmtInteger(v, s, 0x10, c)
+	case 'c':
+		p.fmtC(v)
+	case 'q':
+		p.fmtQc(v)
+	case 'U':
+		p.fmtU(v)
+	default:
+		return false
+	}
+	return true
+}
+func (p *printer) printPointer(u uintptr, t string, c rune) bool {
+	switch c {
+	case 'v':
+		switch {
+		case p.sharpV:
+			p.writeString("(")
+			p.writeString(t)
+			if p.writeString(")("); u == 0 {
+				p.writeString("nil")
+			} else {
+				p.fmt0x64(uint64(u), true)
 			}
+			p.writeString(")")
+		case u == 0:
+			p.pad("<nil>")
+		default:
+			p.fmt0x64(uint64(u), !p.sharp)
+		}
+	case 'p':
+		p.fmt0x64(uint64(u), !p.sharp)
+	case 'b', 'o', 'd', 'x', 'X':
+		return p.printInteger(uint64(u), false, c)
+	default:
+		return false
+	}
+	return true
+}
+func (p *printer) printFloat(v float64, n int, c rune) bool {
+	switch c {
+	case 'v':
+		p.fmtFloat(v, n, 'g', -1)
+	case 'b', 'g', 'G', 'x', 'X':
+		p.fmtFloat(v, n, byte(c), -1)
+	case 'f', 'e', 'E':
+		p.fmtFloat(v, n, byte(c), 6)
+	case 'F':
+		p.fmtFloat(v, n, 'f', 6)
+	default:
+		return false
+	}
+	return true
+}
+func (p *printer) printComplex(v complex128, n int, c rune) bool {
+	switch c {
+	case 'v', 'b', 'g', 'G', 'x', 'X', 'f', 'F', 'e', 'E':
+	default:
+		return false
+	}
+	k := p.plus
+	p.writeString("(")
+	p.printFloat(real(v), n/2, c)
+	p.plus = true
+	p.printFloat(imag(v), n/2, c)
+	p.writeString("i)")
+	p.plus = k
+	return true
+}
+func (p *printer) printArg(v interface{}, c rune) {
+	if v == nil {
+		if c == 'T' || c == 'v' {
+			p.pad("<nil>")
+		} else {
+			p.badVerb(v, c)
+		}
+		return
+	}
+	if c == 'T' {
+		p.fmtS(reflectlite.TypeOf(v).String())
+		return
+	}
+	if c == 'p' {
+		if u, ok := pointer(v, c); ok {
+			p.printPointer(u, reflectlite.TypeOf(v).String(), c)
+		} else {
+			p.badVerb(v, c)
+		}
+		return
+	}
+	var ok bool
+	switch r := v.(type) {
+	case bool:
+		ok = p.printBool(r, c)
+	case float32:
+		ok = p.printFloat(float64(r), 32, c)
+	case float64:
+		ok = p.printFloat(r, 64, c)
+	case complex64:
+		ok = p.printComplex(complex128(r), 64, c)
+	case complex128:
+		ok = p.printComplex(r, 128, c)
+	case string:
+		ok = p.printString(r, c)
+	case []byte:
+		ok = p.printBytes(r, c)
+	default:
+		if k, n, y := integer(v); y {
+			ok = p.printInteger(k, n, c)
+		} else if ok = p.handleMethods(v, c); !ok {
+			ok = p.printValue(v, c)
+		}
+	}
+	if !ok {
+		p.badVerb(v, c)
+	}
+}
+func (p *printer) printValue(v interface{}, c rune) bool {
+	if p.printReflect(v, c) {
+		return true
+	}
+	if ok, y := p.printKind(v, c); y {
+		return ok
+	}
+	if u, ok := pointer(v, c); ok {
+		return p.printPointer(u, reflectlite.TypeOf(v).String(), c)
+	}
+	if p.sharpV {
+		p.writeString("?")
+		p.writeString(reflectlite.TypeOf(v).String())
+		p.writeString("?")
+	}
+	return true
+}
+func (p *printer) printKind(v interface{}, c rune) (bool, bool) {
+	e := (*abi.EmptyInterface)(unsafe.Pointer(&v))
+	if e.Type == nil || e.Data == nil {
+		return false, false
+	}
+	if k, n, ok := kindInteger(v); ok {
+		return p.printInteger(k, n, c), true
+	}
+	switch e.Type.Kind() {
+	case abi.Bool:
+		return p.printBool(*(*bool)(e.Data), c), true
+	case abi.Float32:
+		return p.printFloat(float64(*(*float32)(e.Data)), 32, c), true
+	case abi.Float64:
+		return p.printFloat(*(*float64)(e.Data), 64, c), true
+	case abi.Complex64:
+		return p.printComplex(complex128(*(*complex64)(e.Data)), 64, c), true
+	case abi.Complex128:
+		return p.printComplex(*(*complex128)(e.Data), 128, c), true
+	case abi.String:
+		return p.printString(*(*string)(e.Data), c), true
+	}
+	return false, false
+}
+func (p *printer) catchPanic(v interface{}, c rune, m string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if e := (*abi.EmptyInterface)(unsafe.Pointer(&v)); e.Type.Kind() == abi.Pointer && e.Data == nil {
+		p.writeString("<nil>")
+		return
+	}
+	if p.panicking {
+		panic(r)
+	}
+	f := p.flags
+	p.flags = flags{}
+	p.writeString("%!")
+	p.writeRune(c)
+	p.writeString("(PANIC=")
+	p.writeString(m)
+	p.writeString(" method: ")
+	p.panicking = true
+	p.printArg(r, 'v')
+	p.panicking = false
+	p.writeString(")")
+	p.flags = f
+}
+func (p *printer) handleMethods(v interface{}, c rune) (h bool) {
+	if p.erroring {
+		return false
+	}
+	if c == 'w' {
+		if _, ok := v.(error); !ok || !p.wrap {
+			p.badVerb(v, c)
+			return true
 		}
-		if c += n; err != nil {
-			return c, err
+		c = 'v'
+	}
+	if f, ok := v.(Formatter); ok {
+		h = true
+		defer p.catchPanic(v, c, "Format")
+		f.Format(p, c)
+		return
+	}
+	if p.sharpV {
+		if g, ok := v.(GoStringer); ok {
+			h = true
+			defer p.catchPanic(v, c, "GoString")
+			p.fmtS(g.GoString())
 		}
+		return
 	}
-	if f {
-		n, err = b.Write([]byte{'\n'})
-		c += n
+	switch c {
+	case 'v', 's', 'x', 'X', 'q':
+		switch r := v.(type) {
+		case error:
+			h = true
+			defer p.catchPanic(v, c, "Error")
+			p.printString(r.Error(), c)
+		case stringer:
+			h = true
+			defer p.catchPanic(v, c, "String")
+			p.printString(r.String(), c)
+		}
+	}
+	return
+}
+func pointer(v interface{}, c rune) (uintptr, bool) {
+	e := (*abi.EmptyInterface)(unsafe.Pointer(&v))
+	if e.Type == nil {
+		return 0, false
+	}
+	switch e.Type.Kind() {
+	case abi.Chan, abi.Map, abi.Pointer, abi.UnsafePointer:
+		return uintptr(e.Data), true
+	case abi.Func:
+		if e.Data == nil {
+			return 0, true
+		}
+		return *(*uintptr)(e.Data), true
+	case abi.Slice:
+		return *(*uintptr)(e.Data), c == 'p'
 	}
-	return c, err
+	return 0, false
 }
-func quickFprintf(b io.Writer, s string, v ...interface{}) (int, error) {
-	if len(v) == 0 {
-		return io.WriteString(b, s)
+func integer(v interface{}) (uint64, bool, bool) {
+	switch r := v.(type) {
+	case int:
+		return uint64(r), true, true
+	case int8:
+		return uint64(r), true, true
+	case int16:
+		return uint64(r), true, true
+	case int32:
+		return uint64(r), true, true
+	case int64:
+		return uint64(r), true, true
+	case uint:
+		return uint64(r), false, true
+	case uint8:
+		return uint64(r), false, true
+	case uint16:
+		return uint64(r), false, true
+	case uint32:
+		return uint64(r), false, true
+	case uint64:
+		return r, false, true
+	case uintptr:
+		return uint64(r), false, true
 	}
+	return 0, false, false
+}
+func parsenum(s string, i int) (int, bool, int) {
 	var (
-		n, c, x, a int
-		err        error
+		n  int
+		ok bool
 	)
-	for i := 0; i < len(s); i++ {
-		if a >= len(v) {
-			break
+	for ; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+		if n > 1e6 {
+			return 0, false, len(s)
 		}
-		if s[i] != '%' {
+		n, ok = n*0xA+int(s[i]-'0'), true
+	}
+	return n, ok, i
+}
+func kindInteger(v interface{}) (uint64, bool, bool) {
+	e := (*abi.EmptyInterface)(unsafe.Pointer(&v))
+	if e.Type == nil || e.Data == nil {
+		return 0, false, false
+	}
+	switch e.Type.Kind() {
+	case abi.Int:
+		return uint64(*(*int)(e.Data)), true, true
+	case abi.Int8:
+		return uint64(*(*int8)(e.Data)), true, true
+	case abi.Int16:
+		return uint64(*(*int16)(e.Data)), true, true
+	case abi.Int32:
+		return uint64(*(*int32)(e.Data)), true, true
+	case abi.Int64:
+		return uint64(*(*int64)(e.Data)), true, true
+	case abi.Uint:
+		return uint64(*(*uint)(e.Data)), false, true
+	case abi.Uint8:
+		return uint64(*(*uint8)(e.Data)), false, true
+	case abi.Uint16:
+		return uint64(*(*uint16)(e.Data)), false, true
+	case abi.Uint32:
+		return uint64(*(*uint32)(e.Data)), false, true
+	case abi.Uint64:
+		return *(*uint64)(e.Data), false, true
+	case abi.Uintptr:
+		return uint64(*(*uintptr)(e.Data)), false, true
+	}
+	return 0, false, false
+}
+func intFromArg(v []interface{}, a int) (int, bool, int) {
+	if a >= len(v) {
+		return 0, false, a
+	}
+	k, s, ok := integer(v[a])
+	if !ok {
+		k, s, ok = kindInteger(v[a])
+	}
+	if n := int64(k); ok && ((s && n >= -1e6 && n <= 1e6) || (!s && k <= 1e6)) {
+		return int(n), true, a + 1
+	}
+	return 0, false, a + 1
+}
+func parseArgNumber(s string) (int, int, bool) {
+	if len(s) < 3 {
+		return 0, 1, false
+	}
+	for i := 1; i < len(s); i++ {
+		if s[i] != ']' {
 			continue
 		}
-		if i+1 >= len(s) {
+		n, ok, x := parsenum(s[:i], 1)
+		if !ok || x != i {
+			return 0, i + 1, false
+		}
+		return n - 1, i + 1, true
+	}
+	return 0, 1, false
+}
+func quickPrint(nl bool, v ...interface{}) string {
+	p := newPrinter()
+	p.print(nl, v)
+	r := string(p.buf)
+	p.free()
+	return r
+}
+func quickAppend(b []byte, nl bool, v ...interface{}) []byte {
+	p := newPrinter()
+	o := p.buf
+	p.buf = b
+	p.print(nl, v)
+	b, p.buf = p.buf, o
+	p.free()
+	return b
+}
+func quickAppendf(b []byte, s string, v ...interface{}) []byte {
+	p := newPrinter()
+	o := p.buf
+	p.buf = b
+	p.printf(s, v)
+	b, p.buf = p.buf, o
+	p.free()
+	return b
+}
+func quickPrintf(s string, v ...interface{}) string {
+	p := newPrinter()
+	p.printf(s, v)
+	r := string(p.buf)
+	p.free()
+	return r
+}
+func quickFprint(w io.Writer, f bool, v ...interface{}) (int, error) {
+	p := newPrinter()
+	p.print(f, v)
+	n, err := w.Write(p.buf)
+	p.free()
+	return n, err
+}
+func quickErrorf(s string, v ...interface{}) error {
+	p := newPrinter()
+	defer p.free()
+	p.wrap = true
+	p.printf(s, v)
+	switch len(p.errs) {
+	case 0:
+		return errors.New(string(p.buf))
+	case 1:
+		e, _ := v[p.errs[0]].(error)
+		return &wrapError{s: string(p.buf), e: e}
+	}
+	if p.reordered {
+		for i := 1; i < len(p.errs); i++ {
+			for j := i; j > 0 && p.errs[j] < p.errs[j-1]; j-- {
+				p.errs[j], p.errs[j-1] = p.errs[j-1], p.errs[j]
+			}
+		}
+	}
+	var e []error
+	for i, a := range p.errs {
+		if i > 0 && p.errs[i-1] == a {
 			continue
 		}
-		n, err = io.WriteString(b, s[x:i])
-		if c += n; err != nil {
-			return c, err
+		if r, ok := v[a].(error); ok {
+			e = append(e, r)
 		}
-		x = i
-		if i++; s[i] >= '0' && s[i] <= '9' {
-			for x = i; i < len(s); i++ {
-				if s[i] >= '0' && s[i] <= '9' {
-					continue
-				}
-				break
-			}
+	}
+	return &wrapErrors{s: string(p.buf), e: e}
+}
+func quickFprintf(w io.Writer, s string, v ...interface{}) (int, error) {
+	p := newPrinter()
+	p.printf(s, v)
+	n, err := w.Write(p.buf)
+	p.free()
+	return n, err
+}
+func (p *printer) compile(s string) *program {
+	var r program
+	p.ops = p.ops[:0]
+	for i := 0; i < len(s); {
+		x := i
+		for i < len(s) && s[i] != '%' {
+			i++
 		}
 		if i >= len(s) {
+			r.t = s[x:]
 			break
 		}
-		switch s[i] {
-		case 'q':
-			switch r := v[a].(type) {
-			case []byte:
-				n, err = io.WriteString(b, strconv.Quote(string(r)))
-			case string:
-				n, err = io.WriteString(b, strconv.Quote(r))
-			case error:
-				n, err = io.WriteString(b, strconv.Quote(r.Error()))
-			case stringer:
-				n, err = io.WriteString(b, strconv.Quote(r.String()))
+		e := i
+		p.flags = flags{}
+		if i = p.parse(s, i+1); i < len(s) && (s[i] == '[' || s[i] == '*') {
+			return nil
+		}
+		if p.wid, p.hasWid, i = parsenum(s, i); i+1 < len(s) && s[i] == '.' {
+			if i++; s[i] == '[' || s[i] == '*' {
+				return nil
 			}
-		case 's', 'v':
-			switch r := v[a].(type) {
-			case []byte:
-				n, err = io.WriteString(b, string(r))
-			case string:
-				n, err = io.WriteString(b, r)
-			case error:
-				n, err = io.WriteString(b, r.Error())
-			case stringer:
-				n, err = io.WriteString(b, r.String())
-			case int:
-				n, err = io.WriteString(b, uitoa(uint64(r)))
-			case int8:
-				n, err = io.WriteString(b, uitoa(uint64(r)))
-			case int16:
-				n, err = io.WriteString(b, uitoa(uint64(r)))
-			case int32:
-				n, err = io.WriteString(b, uitoa(uint64(r)))
-			case int64:
-				n, err = io.WriteString(b, uitoa(uint64(r)))
-			case uint:
-				n, err = io.WriteString(b, uitoa(uint64(r)))
-			case uint8:
-				n, err = io.WriteString(b, uitoa(uint64(r)))
-			case uint16:
-				n, err = io.WriteString(b, uitoa(uint64(r)))
-			case uint32:
-				n, err = io.WriteString(b, uitoa(uint64(r)))
-			case uint64:
-				n, err = io.WriteString(b, uitoa(uint64(r)))
-			case uintptr:
-				n, err = io.WriteString(b, uitoa(uint64(r)))
+			p.prec, _, i = parsenum(s, i)
+			p.hasPrec = true
+		}
+		if i >= len(s) {
+			r.t, r.n = s[x:e], true
+			break
+		}
+		if s[i] == '[' {
+			return nil
+		}
+		c, n := utf8.DecodeRuneInString(s[i:])
+		switch i += n; c {
+		case 'v', 'w':
+			p.sharpV, p.sharp = p.sharp, false
+			p.plusV, p.plus = p.plus, false
+		}
+		p.ops = append(p.ops, verb{s: s[x:e], f: p.flags, c: c})
+	}
+	r.v = append([]verb(nil), p.ops...)
+	return &r
+}
+func (p *printer) program(s string) *program {
+	h := maphash.String(seed, s) | 1
+	i := h & (maxPrograms - 2)
+	for j := i; j <= i+1; j++ {
+		if e := programs[j].Load(); e != nil && e.s == s {
+			if !e.u.Load() {
+				e.u.Store(true)
 			}
-		case 'f', 'e', 'E', 'g', 'G':
-			var k float64
-			switch r := v[a].(type) {
-			case float32:
-				k = float64(r)
-			case float64:
-				k = r
+			return e.r
+		}
+	}
+	k := &sighted[h>>1&(maxPrograms-1)]
+	switch k.Load() {
+	case h:
+	case h &^ 1:
+		return nil
+	default:
+		k.Store(h)
+		return nil
+	}
+	r := p.compile(s)
+	if r == nil {
+		k.Store(h &^ 1)
+		return nil
+	}
+	for e := programs[i].Load(); e != nil && e.u.Swap(false); e = programs[i].Load() {
+		i ^= 1
+	}
+	programs[i].Store(&cached{s: s, r: r})
+	return r
+}
+func (p *printer) run(r *program, v []interface{}) {
+	var a int
+	for i := range r.v {
+		if p.writeString(r.v[i].s); r.v[i].c == '%' {
+			p.writeString("%")
+			continue
+		}
+		if a >= len(v) {
+			p.writeString("%!")
+			p.writeRune(r.v[i].c)
+			p.writeString("(MISSING)")
+			continue
+		}
+		if p.flags = r.v[i].f; r.v[i].c == 'w' {
+			p.errs = append(p.errs, a)
+		}
+		p.printArg(v[a], r.v[i].c)
+		a++
+	}
+	if p.writeString(r.t); r.n {
+		p.writeString("%!(NOVERB)")
+	}
+	p.extra(a, v)
+}
+func (p *printer) extra(a int, v []interface{}) {
+	if p.reordered || a >= len(v) {
+		return
+	}
+	p.flags = flags{}
+	p.writeString("%!(EXTRA ")
+	for i := range v[a:] {
+		if i > 0 {
+			p.writeString(", ")
+		}
+		if v[a+i] == nil {
+			p.writeString("<nil>")
+			continue
+		}
+		p.writeString(reflectlite.TypeOf(v[a+i]).String())
+		p.writeString("=")
+		p.printArg(v[a+i], 'v')
+	}
+	p.writeString(")")
+}
+func (p *printer) printf(s string, v []interface{}) {
+	if len(v) == 0 && strings.IndexByte(s, '%') == -1 {
+		p.writeString(s)
+		return
+	}
+	if r := p.program(s); r != nil {
+		p.run(r, v)
+		return
+	}
+	var (
+		a int
+		k bool
+	)
+	p.reordered = false
+	for i := 0; i < len(s); {
+		p.goodArg = true
+		x := i
+		for i < len(s) && s[i] != '%' {
+			i++
+		}
+		if p.writeString(s[x:i]); i >= len(s) {
+			break
+		}
+		p.flags = flags{}
+		i = p.parse(s, i+1)
+		if a, i, k = p.argNumber(a, s, i, len(v)); i < len(s) && s[i] == '*' {
+			i++
+			if p.wid, p.hasWid, a = intFromArg(v, a); !p.hasWid {
+				p.writeString("%!(BADWIDTH)")
 			}
-			n, err = io.WriteString(b, strconv.FormatFloat(k, s[i], 2, 64))
-		case 'b', 't':
-			if r, ok := v[a].(bool); ok {
-				if r {
-					n, err = io.WriteString(b, "true")
-				} else {
-					n, err = io.WriteString(b, "false")
-				}
+			if p.wid < 0 {
+				p.wid, p.minus, p.zero = -p.wid, true, false
 			}
-		case 'd', 'x', 'X', 'u':
-			var k uint64
-			switch r := v[a].(type) {
-			case int:
-				k = uint64(r)
-			case int8:
-				k = uint64(r)
-			case int16:
-				k = uint64(r)
-			case int32:
-				k = uint64(r)
-			case int64:
-				k = uint64(r)
-			case uint:
-				k = uint64(r)
-			case uint8:
-				k = uint64(r)
-			case uint16:
-				k = uint64(r)
-			case uint32:
-				k = uint64(r)
-			case uint64:
-				k = uint64(r)
-			case uintptr:
-				k = uint64(r)
+			k = false
+		} else if p.wid, p.hasWid, i = parsenum(s, i); k && p.hasWid {
+			p.goodArg = false
+		}
+		if i+1 < len(s) && s[i] == '.' {
+			if i++; k {
+				p.goodArg = false
 			}
-			if s[i] == 'x' || s[i] == 'X' {
-				n, err = io.WriteString(b, strconv.FormatUint(k, 16))
+			if a, i, k = p.argNumber(a, s, i, len(v)); i < len(s) && s[i] == '*' {
+				i++
+				if p.prec, p.hasPrec, a = intFromArg(v, a); p.prec < 0 {
+					p.prec, p.hasPrec = 0, false
+				}
+				if !p.hasPrec {
+					p.writeString("%!(BADPREC)")
+				}
+				k = false
 			} else {
-				n, err = io.WriteString(b, uitoa(k))
+				p.prec, _, i = parsenum(s, i)
+				p.hasPrec = true
 			}
-		default:
-			n, err = io.WriteString(b, s[x:i])
 		}
-		if c += n; err != nil {
-			return c, err
+		if !k {
+			a, i, k = p.argNumber(a, s, i, len(v))
 		}
-		x = i + 1
+		if i >= len(s) {
+			p.writeString("%!(NOVERB)")
+			break
+		}
+		c, n := utf8.DecodeRuneInString(s[i:])
+		switch i += n; {
+		case c == '%':
+			p.writeString("%")
+			continue
+		case !p.goodArg:
+			p.writeString("%!")
+			p.writeRune(c)
+			p.writeString("(BADINDEX)")
+			continue
+		case a >= len(v):
+			p.writeString("%!")
+			p.writeRune(c)
+			p.writeString("(MISSING)")
+			continue
+		}
+		switch c {
+		case 'w':
+			p.errs = append(p.errs, a)
+			fallthrough
+		case 'v':
+			p.sharpV, p.sharp = p.sharp, false
+			p.plusV, p.plus = p.plus, false
+		}
+		p.printArg(v[a], c)
 		a++
 	}
-	if n = 0; x < len(s) {
-		n, err = io.WriteString(b, s[x:])
-	}
-	return c + n, err
+	p.extra(a, v)
 }
@@ -0,0 +1,28 @@
+//go:build !fmtexact
+
+package fmt_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestQuickCompact(t *testing.T) {
+	b := []byte("bs")
+	for _, x := range []printTest{
+		{[]interface{}{1, 2, "a", "b", 3}, "1 2 ab 3", "1 2 a b 3\n"},
+		{[]interface{}{stringer("x"), errors.New("e"), b, "y"}, "xebsy", "x e bs y\n"},
+		{[]interface{}{methods{}, valueStringer{"S"}, 1}, "FS 1", "F S 1\n"},
+	} {
+		if s := fmt.Sprint(x.v...); s != x.o {
+			t.Errorf("Sprint(%v) = %q, want %q", x.v, s, x.o)
+		}
+		if s := fmt.Sprintln(x.v...); s != x.l {
+			t.Errorf("Sprintln(%v) = %q, want %q", x.v, s, x.l)
+		}
+	}
+	if s := fmt.Sprintf("%v|%d|%c", b, b, b); s != "bs|%!d([]uint8=bs)|%!c([]uint8=bs)" {
+		t.Errorf("Sprintf = %q, want byte slices printed as text", s)
+	}
+}
@@ -0,0 +1,35 @@
+//go:build fmtexact
+
+package fmt_test
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestQuickExact(t *testing.T) {
+	b := []byte("bs")
+	for _, x := range []printTest{
+		{[]interface{}{1, 2, "a", "b", 3}, "1 2ab3", "1 2 a b 3\n"},
+		{[]interface{}{stringer("x"), 1, errorStringer{}, 2}, "x1 E 2", "x 1 E 2\n"},
+		{[]interface{}{[]byte{1, 2}, 3}, "[1 2] 3", "[1 2] 3\n"},
+		{[]interface{}{"a", []byte(nil), "b"}, "a[]b", "a [] b\n"},
+	} {
+		if s := fmt.Sprint(x.v...); s != x.o {
+			t.Errorf("Sprint(%v) = %q, want %q", x.v, s, x.o)
+		}
+		if s := fmt.Sprintln(x.v...); s != x.l {
+			t.Errorf("Sprintln(%v) = %q, want %q", x.v, s, x.l)
+		}
+	}
+	for _, x := range []quickTest{
+		{"%v|%d|%3d|%#v", []interface{}{b, b, b, b}, "[98 115]|[98 115]|[ 98 115]|[]byte{0x62, 0x73}"},
+		{"%c|%o|%b|%U", []interface{}{b, b, b, b}, "[b s]|[142 163]|[1100010 1110011]|[U+0062 U+0073]"},
+		{"%t|%f", []interface{}{b, []byte{1}}, "[%!t(uint8=98) %!t(uint8=115)]|[%!f(uint8=1)]"},
+		{"%s|%q|%x|%X", []interface{}{b, b, b, b}, "bs|\"bs\"|6273|6273"},
+	} {
+		if s := fmt.Sprintf(x.f, x.v...); s != x.o {
+			t.Errorf("Sprintf(%q) = %q, want %q", x.f, s, x.o)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+//go:build fmtreflect
+
+package fmt_test
+
+import (
+	"fmt"
+	"testing"
+)
+
+type pair struct {
+	A int
+	B string
+}
+
+var reflectTests = []quickTest{
+	// slices, arrays and maps
+	{"%v|%v", []interface{}{map[string]int{"b": 2, "a": 1}, map[int]bool{3: true, -1: false}}, "map[a:1 b:2]|map[-1:false 3:true]"},
+	{"%x|%5d|%.1f", []interface{}{[]int{10, 11}, [2]int{1, 2}, []float64{0.25}}, "[a b]|[    1     2]|[0.2]"},
+	{"%#v|%#v|%#v", []interface{}{[]int{1, 2}, []string(nil), [1]bool{true}}, "[]int{1, 2}|[]string(nil)|[1]bool{true}"},
+	{"%v|%#v|%#v", []interface{}{map[string]int(nil), map[string]int(nil), map[string]int{"a": 1}}, "map[]|map[string]int(nil)|map[string]int{\"a\":1}"},
+	{"%v|%q|%d", []interface{}{[]interface{}{1, "a", nil}, []string{"a"}, []interface{}{"s"}}, "[1 a <nil>]|[\"a\"]|[%!d(string=s)]"},
+
+	// structs and pointers
+	{"%v|%v|%v", []interface{}{&[]int{1}, &map[string]int{"a": 1}, &[1]string{"x"}}, "&[1]|&map[a:1]|&[x]"},
+	{"%v|%+v|%#v", []interface{}{pair{1, "x"}, pair{1, "x"}, pair{1, "x"}}, "{1 x}|{A:1 B:x}|fmt_test.pair{A:1, B:\"x\"}"},
+	{"%v|%+v|%v", []interface{}{&pair{2, "y"}, []pair{{3, "z"}}, struct{}{}}, "&{2 y}|[{A:3 B:z}]|{}"},
+	{"%#v|%d", []interface{}{struct{ P []int }{nil}, pair{10, "a"}}, "struct { P []int }{P:[]int(nil)}|{10 %!d(string=a)}"},
+}
+
+func TestQuickReflect(t *testing.T) {
+	for _, x := range reflectTests {
+		if s := fmt.Sprintf(x.f, x.v...); s != x.o {
+			t.Errorf("Sprintf(%q) = %q, want %q", x.f, s, x.o)
+		}
+	}
+}
+func TestQuickCycles(t *testing.T) {
+	m := map[string]interface{}{"a": 1}
+	m["self"] = m
+	s := []interface{}{1, nil}
+	s[1] = s
+	a := []int{1}
+	for _, x := range []quickTest{
+		{"%v", []interface{}{m}, "map[a:1 self:<cycle>]"},
+		{"%v|%d", []interface{}{s, s}, "[1 <cycle>]|[1 <cycle>]"},
+		{"%#v", []interface{}{s}, "[]interface {}{1, []interface {}(<cycle>)}"},
+		{"%+v", []interface{}{struct{ M map[string]interface{} }{m}}, "{M:map[a:1 self:<cycle>]}"},
+		{"%v", []interface{}{[]interface{}{a, a, s[:1]}}, "[[1] [1] [1]]"},
+	} {
+		if r := fmt.Sprintf(x.f, x.v...); r != x.o {
+			t.Errorf("Sprintf(%q) = %q, want %q", x.f, r, x.o)
+		}
+	}
+}
@@ -0,0 +1,462 @@
+package fmt_test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+type port uint16
+
+type goStringer struct{}
+
+func (goStringer) GoString() string { return "GS{}" }
+func (goStringer) String() string   { return "gs" }
+
+type stringer string
+
+func (s stringer) String() string { return string(s) }
+
+type flagFormatter struct{}
+
+func (flagFormatter) Format(s fmt.State, c rune) {
+	b := []byte{byte(c)}
+	for _, f := range "+#- 0" {
+		if s.Flag(int(f)) {
+			b = append(b, byte(f))
+		}
+	}
+	if w, ok := s.Width(); ok {
+		b = strconv.AppendInt(append(b, 'w'), int64(w), 10)
+	}
+	if p, ok := s.Precision(); ok {
+		b = strconv.AppendInt(append(b, 'p'), int64(p), 10)
+	}
+	s.Write(b)
+}
+
+type quickTest struct {
+	f string
+	v []interface{}
+	o string
+}
+
+var quickTests = []quickTest{
+	// flags, width and precision
+	{"%08x", []interface{}{255}, "000000ff"},
+	{"%-20s|", []interface{}{"left"}, "left                |"},
+	{"%5s|%-5s|", []interface{}{"é", "é"}, "    é|é    |"},
+	{"%.2s|%.5s", []interface{}{"hello", "hi"}, "he|hi"},
+	{"%+d|% d|%+d|% d", []interface{}{5, 5, -5, -5}, "+5| 5|-5|-5"},
+	{"%06d|%-6d|%-06d|", []interface{}{-42, -3, 5}, "-00042|-3    |5     |"},
+	{"%8.3d|%.0d|%5.0d|", []interface{}{7, 0, 0}, "     007||     |"},
+	{"%+08d|% 08d", []interface{}{12, 12}, "+0000012| 0000012"},
+	{"%x|%-4x|", []interface{}{-255, 255}, "-ff|ff  |"},
+	{"%.3f|%6.2f|%-8.1f|%08.3f", []interface{}{3.14159, 3.14159, 2.5, -1.5}, "3.142|  3.14|2.5     |-001.500"},
+	{"%g|%.3g|%10.4g|", []interface{}{0.001, 1234.5678, 3.14159}, "0.001|1.23e+03|     3.142|"},
+	{"%5t|%-6t|", []interface{}{true, false}, " true|false |"},
+	{"%5v|%-5v|%05v", []interface{}{1, "a", 2}, "    1|a    |00002"},
+
+	// State passed to Formatter
+	{"%+-8.3v|%#v|%+v", []interface{}{flagFormatter{}, flagFormatter{}, flagFormatter{}}, "v+-w8p3|v#|v+"},
+	{"%s|% 05d|%.0x|%#-q", []interface{}{flagFormatter{}, flagFormatter{}, flagFormatter{}, flagFormatter{}}, "s|d 0w5|xp0|q#-"},
+
+	// floats
+	{"%v|%v|%v|%v", []interface{}{float32(0.1), 0.1, 1e6, 1e21}, "0.1|0.1|1e+06|1e+21"},
+	{"%v|%v|%v", []interface{}{100000.0, float32(1) / 3, -0.0}, "100000|0.33333334|0"},
+	{"%x|%X|%.3x|%x", []interface{}{1.0, 1.5, 3.14159, float32(0.5)}, "0x1p+00|0X1.8P+00|0x1.922p+01|0x1p-01"},
+	{"%v|%+v|% v|%5v|%-6v|%06v", []interface{}{math.Inf(1), math.NaN(), math.NaN(), math.Inf(-1), math.NaN(), math.Inf(1)}, "+Inf|NaN| NaN| -Inf|NaN   |  +Inf"},
+	{"%#.0f|%#g|%#.3x|%b", []interface{}{1.0, 1.0, 1.0, 1.0}, "1.|1.00000|0x1.000p+00|4503599627370496p-52"},
+	{"%+.3e|%.0f|%e", []interface{}{1234.5678, 2.5, 0.0}, "+1.235e+03|2|0.000000e+00"},
+	{"%E|%G|%.1F", []interface{}{1e-7, 1e-7, 2.25}, "1.000000E-07|1E-07|2.2"},
+
+	// complex numbers
+	{"%v|%v|%v", []interface{}{complex(1, -2), complex64(0.1 + 0.2i), complex(0, 0)}, "(1-2i)|(0.1+0.2i)|(0+0i)"},
+	{"%8.2f|%+.1e|%-6v|", []interface{}{complex(1.5, 2.25), complex64(3 + 4i), complex(1, 1)}, "(    1.50   +2.25i)|(+3.0e+00+4.0e+00i)|(1     +1    i)|"},
+	{"%v|%.1f", []interface{}{complex(math.Inf(1), math.NaN()), complex(math.Inf(-1), 1)}, "(+Inf+NaNi)|(-Inf+1.0i)"},
+
+	// pointers
+	{"%p|%#p|%v|%d", []interface{}{(*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil)}, "0x0|0|<nil>|0"},
+	{"%v|%v|%p|%p", []interface{}{(func())(nil), (chan int)(nil), (map[int]int)(nil), unsafe.Pointer(nil)}, "<nil>|<nil>|0x0|0x0"},
+	{"%#v|%#v|%5v|", []interface{}{(chan int)(nil), (func())(nil), (*int)(nil)}, "(chan int)(nil)|(func())(nil)|<nil>|"},
+
+	// %w outside of Errorf
+	{"%w|%w", []interface{}{"x", nil}, "%!w(string=x)|%!w(<nil>)"},
+
+	// bad verbs, missing and extra operands
+	{"%d", []interface{}{"s"}, "%!d(string=s)"},
+	{"%s|%t", []interface{}{1, 2.5}, "%!s(int=1)|%!t(float64=2.5)"},
+	{"%z", []interface{}{true}, "%!z(bool=true)"},
+	{"%d", []interface{}{nil}, "%!d(<nil>)"},
+	{"%d %d", []interface{}{1}, "1 %!d(MISSING)"},
+	{"%s", nil, "%!s(MISSING)"},
+	{"%d", []interface{}{1, "a", nil}, "1%!(EXTRA string=a, <nil>)"},
+	{"x", []interface{}{2}, "x%!(EXTRA int=2)"},
+	{"%", []interface{}{1}, "%!(NOVERB)%!(EXTRA int=1)"},
+	{"%-", nil, "%!(NOVERB)"},
+	{"%d|%d", []interface{}{1.5, complex(1, 2)}, "%!d(float64=1.5)|%!d(complex128=(1+2i))"},
+	{"%p|%p|%s", []interface{}{1, "s", (*int)(nil)}, "%!p(int=1)|%!p(string=s)|%!s(*int=<nil>)"},
+
+	// literal percent
+	{"100%%", nil, "100%"},
+	{"%d%% of %d", []interface{}{5, 10}, "5% of 10"},
+	{"%%%d%%", []interface{}{1}, "%1%"},
+	{"%5%|%-5%", nil, "%|%"},
+	{"%%", []interface{}{1}, "%%!(EXTRA int=1)"},
+
+	// argument indexes and '*' width and precision
+	{"%[2]s %[1]d", []interface{}{1, "a"}, "a 1"},
+	{"%[2]d %d %[1]d %d", []interface{}{1, 2}, "2 %!d(MISSING) 1 2"},
+	{"%[3]d|%[0]d|%[x]d", []interface{}{1, 2}, "%!d(BADINDEX)|%!d(BADINDEX)|%!d(BADINDEX)"},
+	{"%[1]d", []interface{}{1, 2}, "1"},
+	{"%*d|%-*d|%*d|", []interface{}{4, 1, 4, 2, -4, 3}, "   1|2   |3   |"},
+	{"%.*f|%.*f", []interface{}{2, 3.14159, -1, 2.5}, "3.14|%!(BADPREC)2.500000"},
+	{"%[2]*[1]d|%[3]*.[2]*[1]f", []interface{}{5, 3, 8}, "  5|%!f(int=     005)"},
+	{"%*d", []interface{}{"x", 1}, "%!(BADWIDTH)1"},
+	{"%.*d", []interface{}{"x", 1}, "%!(BADPREC)1"},
+	{"%*d", []interface{}{10000000, 1}, "%!(BADWIDTH)1"},
+	{"%*d|%.*f|", []interface{}{port(5), 1, port(2), 3.14159}, "    1|3.14|"},
+
+	// Go syntax with %#v
+	{"%#v|%#v|%#v", []interface{}{"a\"b", 42, uint8(31)}, "\"a\\\"b\"|42|0x1f"},
+	{"%#v|%#v|%#v", []interface{}{1.0, float32(2.5), 1e21}, "1|2.5|1e+21"},
+	{"%#v|%#v", []interface{}{true, complex(1, -2)}, "true|(1-2i)"},
+	{"%#v|%#v", []interface{}{[]byte{1, 0xa}, []byte(nil)}, "[]byte{0x1, 0xa}|[]byte(nil)"},
+	{"%#v|%v", []interface{}{goStringer{}, goStringer{}}, "GS{}|gs"},
+	{"%#v", []interface{}{(*int)(nil)}, "(*int)(nil)"},
+
+	// named basic types
+	{"%v|%05d|%x", []interface{}{port(80), port(80), port(255)}, "80|00080|ff"},
+	{"%w|%s|%d", []interface{}{codeError(2), codeError(3), codeError(4)}, "%!w(fmt_test.codeError=2)|code 3|4"},
+
+	// hex strings and byte slices
+	{"%x|%X|% x|%#x", []interface{}{"hi!", "hi!", "hi!", "hi!"}, "686921|686921|68 69 21|0x686921"},
+	{"%x|%X", []interface{}{[]byte{0xde, 0xad}, []byte{0xbe, 0xef}}, "dead|BEEF"},
+	{"% #x|% #X|%#X", []interface{}{[]byte{1, 0xab}, []byte{1, 0xab}, []byte{1, 0xab}}, "0x01 0xab|0X01 0XAB|0X01AB"},
+	{"%.2x|%8x|%-8x|%08x|", []interface{}{"abc", "ab", "ab", "ab"}, "6162|    6162|6162    |00006162|"},
+	{"%x|%4x|", []interface{}{"", []byte(nil)}, "|    |"},
+	{"%x", []interface{}{stringer("ab")}, "6162"},
+
+	// quoted runes and strings
+	{"%q|%q|%q|%q", []interface{}{'x', '\n', 'é', -1}, "'x'|'\\n'|'é'|'\uFFFD'"},
+	{"%+q|%+q|%#q", []interface{}{'é', "héllo", 'x'}, "'\\u00e9'|\"h\\u00e9llo\"|'x'"},
+	{"%#q|%#q|%#+q", []interface{}{"plain", "tick`", "é"}, "`plain`|\"tick`\"|`é`"},
+	{"%6q|%-6q|%.2q", []interface{}{'a', 'b', "abcd"}, "   'a'|'b'   |\"ab\""},
+
+	// %c and %U
+	{"%c|%c|%c|%c", []interface{}{'A', 'é', 0x1F600, uint8(66)}, "A|é|\U0001F600|B"},
+	{"%c|%c|%c", []interface{}{-1, 0xD800, uint64(1 << 40)}, "\uFFFD|\uFFFD|\uFFFD"},
+	{"%U|%#U|%#U|%#U", []interface{}{'A', 'A', '\n', 0x110000}, "U+0041|U+0041 'A'|U+000A|U+110000"},
+	{"%.6U|%10U|%-10U|%010U", []interface{}{'A', 'x', 'x', 'x'}, "U+000041|    U+0078|U+0078    |    U+0078"},
+	{"%5c|%-5c|%c", []interface{}{'x', 'y', port(67)}, "    x|y    |C"},
+
+	// %T and integer bases
+	{"%T|%T|%T|%T", []interface{}{1, "s", nil, port(1)}, "int|string|<nil>|fmt_test.port"},
+	{"%T|%10T|%-6T|", []interface{}{[]int{}, 1.5, true}, "[]int|   float64|bool  |"},
+	{"%b|%#b|%o|%#o|%O", []interface{}{5, 5, 8, 8, 8}, "101|0b101|10|010|0o10"},
+	{"%b|%o|%O|%+O|%#o", []interface{}{-5, -8, -8, 9, 0}, "-101|-10|-0o10|+0o11|0"},
+	{"%x|%X|%#x|%#X|%#08X", []interface{}{255, 255, 255, 255, 255}, "ff|FF|0xff|0XFF|0X000000FF"},
+	{"%#08x|%#-8x|%#x", []interface{}{255, 255, -255}, "0x000000ff|0xff    |-0xff"},
+	{"%X|%b", []interface{}{port(0xab), port(3)}, "AB|11"},
+	{"%t|%b", []interface{}{true, true}, "true|%!b(bool=true)"},
+}
+
+func TestQuickSprintf(t *testing.T) {
+	for _, x := range quickTests {
+		if s := fmt.Sprintf(x.f, x.v...); s != x.o {
+			t.Errorf("Sprintf(%q) = %q, want %q", x.f, s, x.o)
+		}
+	}
+}
+
+type codeError int
+
+func (c codeError) Error() string { return "code " + strconv.Itoa(int(c)) }
+
+var (
+	errA = errors.New("a")
+	errB = errors.New("b")
+)
+
+type errorfTest struct {
+	f string
+	v []interface{}
+	o string
+	k int
+	w []error
+}
+
+var errorfTests = []errorfTest{
+	{"x %d", []interface{}{1}, "x 1", 0, nil},
+	{"x: %w", []interface{}{errA}, "x: a", 1, []error{errA}},
+	{"%v: %w", []interface{}{errA, errB}, "a: b", 1, []error{errB}},
+	{"%w %w", []interface{}{errA, errB}, "a b", 2, []error{errA, errB}},
+	{"%w|%w|%w", []interface{}{errB, codeError(1), errA}, "b|code 1|a", 2, []error{errB, codeError(1), errA}},
+	{"%w", []interface{}{"x"}, "%!w(string=x)", 1, nil},
+	{"%w", []interface{}{nil}, "%!w(<nil>)", 1, nil},
+	{"%w %w", []interface{}{errA, 1}, "a %!w(int=1)", 2, []error{errA}},
+	{"%[2]w", []interface{}{errA, errB}, "b", 1, []error{errB}},
+	{"%[2]w %[1]w", []interface{}{errA, errB}, "b a", 2, []error{errA, errB}},
+	{"%[2]w %[1]w %[2]w", []interface{}{errA, errB}, "b a b", 2, []error{errA, errB}},
+	{"%[1]w %[1]w", []interface{}{errA}, "a a", 2, []error{errA}},
+}
+
+func unwrapped(e error) (int, []error) {
+	switch u := e.(type) {
+	case interface{ Unwrap() []error }:
+		return 2, u.Unwrap()
+	case interface{ Unwrap() error }:
+		if r := u.Unwrap(); r != nil {
+			return 1, []error{r}
+		}
+		return 1, nil
+	}
+	return 0, nil
+}
+func TestQuickErrorf(t *testing.T) {
+	for _, x := range errorfTests {
+		e := fmt.Errorf(x.f, x.v...)
+		if e.Error() != x.o {
+			t.Errorf("Errorf(%q) = %q, want %q", x.f, e.Error(), x.o)
+		}
+		k, w := unwrapped(e)
+		if k != x.k || len(w) != len(x.w) {
+			t.Errorf("Errorf(%q): Unwrap kind %d with %v, want %d with %v", x.f, k, w, x.k, x.w)
+			continue
+		}
+		for i := range w {
+			if w[i] != x.w[i] {
+				t.Errorf("Errorf(%q): Unwrap()[%d] = %v, want %v", x.f, i, w[i], x.w[i])
+			}
+		}
+	}
+	for _, e := range []error{fmt.Errorf("x: %w", codeError(7)), fmt.Errorf("%w, %w", errA, codeError(7))} {
+		var c codeError
+		if !errors.As(e, &c) || c != 7 {
+			t.Errorf("errors.As(%q) = %v, want code 7", e, c)
+		}
+		if errors.Is(e, errB) || !errors.Is(e, codeError(7)) {
+			t.Errorf("errors.Is(%q) gave the wrong result", e)
+		}
+	}
+	if e := fmt.Errorf("%w: %w", errA, fmt.Errorf("y: %w", errB)); !errors.Is(e, errA) || !errors.Is(e, errB) {
+		t.Errorf("errors.Is(%q) did not find both wrapped errors", e)
+	}
+}
+
+type methods struct{}
+type errorStringer struct{}
+type valueStringer struct{ s string }
+
+func (methods) Format(s fmt.State, c rune) { s.Write([]byte("F")) }
+func (methods) Error() string              { return "E" }
+func (methods) String() string             { return "S" }
+func (errorStringer) Error() string        { return "E" }
+func (errorStringer) String() string       { return "S" }
+func (v valueStringer) String() string     { return v.s }
+
+type printTest struct {
+	v []interface{}
+	o string
+	l string
+}
+
+var printTests = []printTest{
+	{nil, "", "\n"},
+	{[]interface{}{nil}, "<nil>", "<nil>\n"},
+	{[]interface{}{1, 2, -3}, "1 2 -3", "1 2 -3\n"},
+	{[]interface{}{1.5, true, complex(1, 2), nil}, "1.5 true (1+2i) <nil>", "1.5 true (1+2i) <nil>\n"},
+	{[]interface{}{"a", "b"}, "ab", "a b\n"},
+	{[]interface{}{methods{}}, "F", "F\n"},
+	{[]interface{}{errorStringer{}}, "E", "E\n"},
+	{[]interface{}{valueStringer{"S"}}, "S", "S\n"},
+	{[]interface{}{(*valueStringer)(nil), (*int)(nil)}, "<nil> <nil>", "<nil> <nil>\n"},
+	{[]interface{}{port(80), uint8(1), int64(-1)}, "80 1 -1", "80 1 -1\n"},
+}
+
+func TestQuickSprint(t *testing.T) {
+	var b strings.Builder
+	for _, x := range printTests {
+		if s := fmt.Sprint(x.v...); s != x.o {
+			t.Errorf("Sprint(%v) = %q, want %q", x.v, s, x.o)
+		}
+		if s := fmt.Sprintln(x.v...); s != x.l {
+			t.Errorf("Sprintln(%v) = %q, want %q", x.v, s, x.l)
+		}
+		if s := string(fmt.Append([]byte("x"), x.v...)); s != "x"+x.o {
+			t.Errorf("Append(%v) = %q, want %q", x.v, s, "x"+x.o)
+		}
+		if s := string(fmt.Appendln([]byte("x"), x.v...)); s != "x"+x.l {
+			t.Errorf("Appendln(%v) = %q, want %q", x.v, s, "x"+x.l)
+		}
+		b.Reset()
+		if n, err := fmt.Fprint(&b, x.v...); b.String() != x.o || n != len(x.o) || err != nil {
+			t.Errorf("Fprint(%v) = %q, %d, %v, want %q", x.v, b.String(), n, err, x.o)
+		}
+		b.Reset()
+		if n, err := fmt.Fprintln(&b, x.v...); b.String() != x.l || n != len(x.l) || err != nil {
+			t.Errorf("Fprintln(%v) = %q, %d, %v, want %q", x.v, b.String(), n, err, x.l)
+		}
+	}
+}
+
+type panicString struct{}
+type panicError struct{}
+type panicFormat struct{}
+type panicGoString struct{}
+type panicNested struct{}
+
+func (panicString) String() string         { panic("boom") }
+func (panicError) Error() string           { panic("boom") }
+func (panicFormat) Format(fmt.State, rune) { panic("boom") }
+func (panicGoString) GoString() string     { panic("boom") }
+func (panicNested) Error() string          { panic(panicNested{}) }
+
+func TestQuickPanics(t *testing.T) {
+	for _, x := range []quickTest{
+		{"%v|%s", []interface{}{panicString{}, panicString{}}, "%!v(PANIC=String method: boom)|%!s(PANIC=String method: boom)"},
+		{"%v|%q", []interface{}{panicError{}, panicError{}}, "%!v(PANIC=Error method: boom)|%!q(PANIC=Error method: boom)"},
+		{"%v|%d", []interface{}{panicFormat{}, panicFormat{}}, "%!v(PANIC=Format method: boom)|%!d(PANIC=Format method: boom)"},
+		{"%#v", []interface{}{panicGoString{}}, "%!v(PANIC=GoString method: boom)"},
+		{"%5s|%5s", []interface{}{panicString{}, "a"}, "%!s(PANIC=String method: boom)|    a"},
+		{"%v|%s|%#v", []interface{}{(*valueStringer)(nil), (*valueStringer)(nil), (*valueStringer)(nil)}, "<nil>|<nil>|(*fmt_test.valueStringer)(nil)"},
+	} {
+		if s := fmt.Sprintf(x.f, x.v...); s != x.o {
+			t.Errorf("Sprintf(%q) = %q, want %q", x.f, s, x.o)
+		}
+	}
+	if s := fmt.Sprint(panicError{}); s != "%!v(PANIC=Error method: boom)" {
+		t.Errorf("Sprint = %q, want %q", s, "%!v(PANIC=Error method: boom)")
+	}
+	defer func() {
+		if r := recover(); r != (panicNested{}) {
+			t.Errorf("nested panic recovered %v, want panicNested{}", r)
+		}
+	}()
+	_ = fmt.Sprint(panicNested{})
+	t.Error("nested panic was not raised again")
+}
+
+type countWriter struct {
+	b []byte
+	n int
+}
+type shortWriter struct{}
+
+var errShort = errors.New("short")
+
+func (w *countWriter) Write(b []byte) (int, error) {
+	w.b, w.n = append(w.b, b...), w.n+1
+	return len(b), nil
+}
+func (shortWriter) Write([]byte) (int, error) {
+	return 3, errShort
+}
+func TestQuickWrites(t *testing.T) {
+	for _, x := range []struct {
+		o string
+		f func(*countWriter) (int, error)
+	}{
+		{"1 2 2.5", func(w *countWriter) (int, error) { return fmt.Fprint(w, 1, 2, 2.5) }},
+		{"1 a 2.5\n", func(w *countWriter) (int, error) { return fmt.Fprintln(w, 1, "a", 2.5) }},
+		{"00001-abc-78", func(w *countWriter) (int, error) { return fmt.Fprintf(w, "%05d-%s-%x", 1, "abc", "x") }},
+	} {
+		var w countWriter
+		if n, err := x.f(&w); string(w.b) != x.o || n != len(x.o) || err != nil || w.n != 1 {
+			t.Errorf("wrote %q, %d, %v in %d calls, want %q in 1 call", w.b, n, err, w.n, x.o)
+		}
+	}
+	if n, err := fmt.Fprint(shortWriter{}, "hello"); n != 3 || err != errShort {
+		t.Errorf("Fprint = %d, %v, want 3, short", n, err)
+	}
+	if n, err := fmt.Fprintf(shortWriter{}, "%s!", "hello"); n != 3 || err != errShort {
+		t.Errorf("Fprintf = %d, %v, want 3, short", n, err)
+	}
+}
+
+var (
+	appendInt    interface{} = 12345
+	appendString interface{} = "hello"
+	appendFloat  interface{} = 3.25
+	appendBool   interface{} = true
+	appendUint   interface{} = uint16(7)
+)
+
+func TestAppendAllocs(t *testing.T) {
+	b := make([]byte, 0, 0x100)
+	for _, x := range []struct {
+		n string
+		f func()
+	}{
+		{"Append", func() { b = fmt.Append(b[:0], appendInt, appendString, appendFloat, appendBool, appendUint) }},
+		{"Appendln", func() { b = fmt.Appendln(b[:0], appendInt, appendString, appendFloat) }},
+		{"Appendf", func() {
+			b = fmt.Appendf(b[:0], "%d %s %.2f %t %x %q", appendInt, appendString, appendFloat, appendBool, appendUint, appendString)
+		}},
+	} {
+		if n := testing.AllocsPerRun(100, x.f); n != 0 {
+			t.Errorf("%s: got %v allocs, want 0", x.n, n)
+		}
+	}
+}
+func BenchmarkAppend(b *testing.B) {
+	b.ReportAllocs()
+	r := make([]byte, 0, 0x100)
+	for i := 0; i < b.N; i++ {
+		r = fmt.Append(r[:0], appendInt, appendString, appendFloat, appendBool, appendUint)
+	}
+}
+func BenchmarkAppendln(b *testing.B) {
+	b.ReportAllocs()
+	r := make([]byte, 0, 0x100)
+	for i := 0; i < b.N; i++ {
+		r = fmt.Appendln(r[:0], appendInt, appendString, appendFloat)
+	}
+}
+func BenchmarkAppendf(b *testing.B) {
+	b.ReportAllocs()
+	r := make([]byte, 0, 0x100)
+	for i := 0; i < b.N; i++ {
+		r = fmt.Appendf(r[:0], "%d %s %.2f %t %x %q", appendInt, appendString, appendFloat, appendBool, appendUint, appendString)
+	}
+}
+
+var oneOffFormats = func() []string {
+	f := make([]string, 0x1000)
+	for i := range f {
+		f[i] = "request " + strconv.Itoa(i) + " %s took %d ms (%5.2f%%)"
+	}
+	return f
+}()
+
+func TestQuickManyFormats(t *testing.T) {
+	for k := 0; k < 3; k++ {
+		for i, f := range oneOffFormats {
+			if s, w := fmt.Sprintf(f, "GET", 12, 99.5), "request "+strconv.Itoa(i)+" GET took 12 ms (99.50%)"; s != w {
+				t.Fatalf("Sprintf(%q) = %q, want %q", f, s, w)
+			}
+		}
+	}
+}
+func BenchmarkSprintfRepeated(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = fmt.Sprintf("request %s from %s took %d ms (%5.2f%%)", "GET", "host", i, 99.5)
+	}
+}
+func BenchmarkSprintfOneOff(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = fmt.Sprintf(oneOffFormats[i&0xFFF], "GET", i, 99.5)
+	}
+}
+func BenchmarkSprintfStar(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = fmt.Sprintf("%*d|%[1]d", 8, i)
+	}
+}
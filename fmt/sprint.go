@@ -0,0 +1,48 @@
+//go:build !fmtexact
+
+package fmt
+
+func (*printer) printByteList(_ []byte, _ rune) bool {
+	return false
+}
+func (p *printer) print(f bool, v []interface{}) {
+	var s bool
+	for i := range v {
+		var n bool
+		switch v[i].(type) {
+		case []byte, string, Formatter, error, stringer:
+			n = true
+		}
+		if i > 0 && (f || !n || !s) {
+			p.writeString(" ")
+		}
+		s = n
+		switch r := v[i].(type) {
+		case nil:
+			p.writeString("<nil>")
+		case []byte:
+			p.write(r)
+		case string:
+			p.writeString(r)
+		case bool:
+			p.fmtBool(r)
+		case float32:
+			p.printFloat(float64(r), 32, 'v')
+		case float64:
+			p.printFloat(r, 64, 'v')
+		case complex64:
+			p.printComplex(complex128(r), 64, 'v')
+		case complex128:
+			p.printComplex(r, 128, 'v')
+		default:
+			if k, n, ok := integer(r); ok {
+				p.fmtInteger(k, n, 0xA, 'v')
+			} else if !p.handleMethods(r, 'v') {
+				p.printValue(r, 'v')
+			}
+		}
+	}
+	if f {
+		p.writeString("\n")
+	}
+}
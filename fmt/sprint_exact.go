@@ -0,0 +1,42 @@
+//go:build fmtexact
+
+package fmt
+
+import (
+	"internal/abi"
+	"unsafe"
+)
+
+func (p *printer) printByteList(v []byte, c rune) bool {
+	switch c {
+	case 's', 'q', 'x', 'X':
+		return false
+	}
+	p.writeString("[")
+	for i := range v {
+		if i > 0 {
+			p.writeString(" ")
+		}
+		p.printArg(v[i], c)
+	}
+	p.writeString("]")
+	return true
+}
+func (p *printer) print(f bool, v []interface{}) {
+	var s bool
+	for i := range v {
+		n := isString(v[i])
+		if i > 0 && (f || (!n && !s)) {
+			p.writeString(" ")
+		}
+		s = n
+		p.printArg(v[i], 'v')
+	}
+	if f {
+		p.writeString("\n")
+	}
+}
+func isString(v interface{}) bool {
+	e := (*abi.EmptyInterface)(unsafe.Pointer(&v))
+	return e.Type != nil && e.Type.Kind() == abi.String
+}
@@ -0,0 +1,12 @@
+//go:build !fmtsilent
+
+package fmt
+
+import "os"
+
+func quickStdout(f bool, v ...interface{}) (int, error) {
+	return quickFprint(os.Stdout, f, v...)
+}
+func quickStdoutf(s string, v ...interface{}) (int, error) {
+	return quickFprintf(os.Stdout, s, v...)
+}
@@ -0,0 +1,10 @@
+//go:build fmtsilent
+
+package fmt
+
+func quickStdout(_ bool, _ ...interface{}) (int, error) {
+	return 0, nil
+}
+func quickStdoutf(_ string, _ ...interface{}) (int, error) {
+	return 0, nil
+}
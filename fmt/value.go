@@ -0,0 +1,7 @@
+//go:build !fmtreflect
+
+package fmt
+
+func (*printer) printReflect(_ interface{}, _ rune) bool {
+	return false
+}
@@ -0,0 +1,197 @@
+//go:build fmtreflect
+
+package fmt
+
+import (
+	"internal/fmtsort"
+	"reflect"
+)
+
+func (p *printer) printReflect(v interface{}, c rune) bool {
+	p.printRValue(reflect.ValueOf(v), c, 0)
+	return true
+}
+func (p *printer) badValue(r reflect.Value, c rune) {
+	p.erroring = true
+	p.writeString("%!")
+	p.writeRune(c)
+	if p.writeString("("); r.IsValid() {
+		p.writeString(r.Type().String())
+		p.writeString("=")
+		p.printRValue(r, 'v', 0)
+	} else {
+		p.writeString("<nil>")
+	}
+	p.writeString(")")
+	p.erroring = false
+}
+func (p *printer) printRValue(r reflect.Value, c rune, d int) {
+	if d > 0 && r.IsValid() && r.CanInterface() && p.handleMethods(r.Interface(), c) {
+		return
+	}
+	ok := true
+	switch r.Kind() {
+	case reflect.Invalid:
+		switch {
+		case d == 0:
+			p.writeString("<invalid reflect.Value>")
+		case c == 'v':
+			p.writeString("<nil>")
+		default:
+			ok = false
+		}
+	case reflect.Bool:
+		ok = p.printBool(r.Bool(), c)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		ok = p.printInteger(uint64(r.Int()), true, c)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		ok = p.printInteger(r.Uint(), false, c)
+	case reflect.Float32:
+		ok = p.printFloat(r.Float(), 32, c)
+	case reflect.Float64:
+		ok = p.printFloat(r.Float(), 64, c)
+	case reflect.Complex64:
+		ok = p.printComplex(r.Complex(), 64, c)
+	case reflect.Complex128:
+		ok = p.printComplex(r.Complex(), 128, c)
+	case reflect.String:
+		ok = p.printString(r.String(), c)
+	case reflect.Map:
+		o, x, e := "map[", " ", "]"
+		if p.sharpV {
+			if p.writeString(r.Type().String()); r.IsNil() {
+				p.writeString("(nil)")
+				return
+			}
+			o, x, e = "{", ", ", "}"
+		}
+		if !p.enter(r) {
+			p.cycle()
+			return
+		}
+		p.writeString(o)
+		for i, m := range fmtsort.Sort(r) {
+			if i > 0 {
+				p.writeString(x)
+			}
+			p.printRValue(m.Key, c, d+1)
+			p.writeString(":")
+			p.printRValue(m.Value, c, d+1)
+		}
+		p.writeString(e)
+		p.seen = p.seen[:len(p.seen)-1]
+	case reflect.Interface:
+		switch e := r.Elem(); {
+		case e.IsValid():
+			p.printRValue(e, c, d+1)
+		case p.sharpV:
+			p.writeString(r.Type().String())
+			p.writeString("(nil)")
+		default:
+			p.writeString("<nil>")
+		}
+	case reflect.Array, reflect.Slice:
+		if b, y := bytesOf(r, c); y {
+			ok = p.printBytes(b, c)
+			break
+		}
+		o, x, e := "[", " ", "]"
+		if p.sharpV {
+			if p.writeString(r.Type().String()); r.Kind() == reflect.Slice && r.IsNil() {
+				p.writeString("(nil)")
+				return
+			}
+			o, x, e = "{", ", ", "}"
+		}
+		if r.Kind() == reflect.Slice && !p.enter(r) {
+			p.cycle()
+			return
+		}
+		p.writeString(o)
+		for i := 0; i < r.Len(); i++ {
+			if i > 0 {
+				p.writeString(x)
+			}
+			p.printRValue(r.Index(i), c, d+1)
+		}
+		if p.writeString(e); r.Kind() == reflect.Slice {
+			p.seen = p.seen[:len(p.seen)-1]
+		}
+	case reflect.Struct:
+		x := " "
+		if p.sharpV {
+			p.writeString(r.Type().String())
+			x = ", "
+		}
+		p.writeString("{")
+		for i := 0; i < r.NumField(); i++ {
+			if i > 0 {
+				p.writeString(x)
+			}
+			if n := r.Type().Field(i).Name; len(n) > 0 && (p.plusV || p.sharpV) {
+				p.writeString(n)
+				p.writeString(":")
+			}
+			f := r.Field(i)
+			if f.Kind() == reflect.Interface && !f.IsNil() {
+				f = f.Elem()
+			}
+			p.printRValue(f, c, d+1)
+		}
+		p.writeString("}")
+	case reflect.Pointer:
+		if d == 0 && !r.IsNil() {
+			switch e := r.Elem(); e.Kind() {
+			case reflect.Array, reflect.Slice, reflect.Struct, reflect.Map:
+				p.writeString("&")
+				p.printRValue(e, c, d+1)
+				return
+			}
+		}
+		ok = p.printPointer(uintptr(r.UnsafePointer()), r.Type().String(), c)
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		ok = p.printPointer(uintptr(r.UnsafePointer()), r.Type().String(), c)
+	default:
+		p.writeString("?")
+		p.writeString(r.Type().String())
+		p.writeString("?")
+	}
+	if !ok {
+		p.badValue(r, c)
+	}
+}
+func (p *printer) cycle() {
+	if p.sharpV {
+		p.writeString("(<cycle>)")
+	} else {
+		p.writeString("<cycle>")
+	}
+}
+func (p *printer) enter(r reflect.Value) bool {
+	k := [2]uintptr{uintptr(r.UnsafePointer()), uintptr(r.Len())}
+	for i := range p.seen {
+		if k[0] != 0 && p.seen[i] == k {
+			return false
+		}
+	}
+	p.seen = append(p.seen, k)
+	return true
+}
+func bytesOf(r reflect.Value, c rune) ([]byte, bool) {
+	switch c {
+	case 's', 'q', 'x', 'X':
+	default:
+		return nil, false
+	}
+	if r.Type().Elem().Kind() != reflect.Uint8 {
+		return nil, false
+	}
+	if r.Kind() == reflect.Slice || r.CanAddr() {
+		return r.Bytes(), true
+	}
+	b := make([]byte, r.Len())
+	for i := range b {
+		b[i] = byte(r.Index(i).Uint())
+	}
+	return b, true
+}